@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var bucketName = []byte("kagi-cache")
+
+// Bolt is a Cache backed by a single BoltDB file, useful when a flat
+// directory of JSON files becomes unwieldy (many entries, shared over a
+// network filesystem, etc.).
+type Bolt struct {
+	db  *bbolt.DB
+	ttl time.Duration
+}
+
+// NewBolt opens (creating if necessary) a BoltDB-backed Cache at path. A
+// zero ttl disables expiration.
+func NewBolt(path string, ttl time.Duration) (*Bolt, error) {
+	db, err := bbolt.Open(path, 0644, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt database: %w", err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create cache bucket: %w", err)
+	}
+
+	return &Bolt{db: db, ttl: ttl}, nil
+}
+
+func (b *Bolt) Get(query string) (Entry, bool, error) {
+	var (
+		entry Entry
+		found bool
+	)
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(bucketName).Get([]byte(Key(query)))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("failed to unmarshal cache entry: %w", err)
+		}
+		found = true
+		return nil
+	})
+	if err != nil {
+		return Entry{}, false, err
+	}
+	if !found || expired(entry, b.ttl) {
+		return Entry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+func (b *Bolt) Put(query string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketName).Put([]byte(Key(query)), data)
+	})
+}
+
+func (b *Bolt) List() ([]Entry, error) {
+	var entries []Entry
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketName).ForEach(func(_, data []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return fmt.Errorf("failed to unmarshal cache entry: %w", err)
+			}
+			if expired(entry, b.ttl) {
+				return nil
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
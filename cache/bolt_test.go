@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBoltGetPut(t *testing.T) {
+	b, err := NewBolt(filepath.Join(t.TempDir(), "cache.db"), 0)
+	if err != nil {
+		t.Fatalf("NewBolt: %v", err)
+	}
+	defer b.Close()
+
+	if _, ok, err := b.Get("q"); err != nil {
+		t.Fatalf("Get: %v", err)
+	} else if ok {
+		t.Fatal("Get returned ok for an empty cache")
+	}
+
+	if err := b.Put("q", Entry{Question: "q", Answer: "a", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	entry, ok, err := b.Get("q")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !ok {
+		t.Fatal("Get returned !ok after Put")
+	}
+	if entry.Answer != "a" {
+		t.Fatalf("Answer = %q, want %q", entry.Answer, "a")
+	}
+}
+
+func TestBoltExpiresEntriesPastTTL(t *testing.T) {
+	b, err := NewBolt(filepath.Join(t.TempDir(), "cache.db"), time.Minute)
+	if err != nil {
+		t.Fatalf("NewBolt: %v", err)
+	}
+	defer b.Close()
+
+	if err := b.Put("q", Entry{Answer: "a", CreatedAt: time.Now().Add(-2 * time.Minute)}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if _, ok, err := b.Get("q"); err != nil {
+		t.Fatalf("Get: %v", err)
+	} else if ok {
+		t.Fatal("expected expired entry to be a cache miss")
+	}
+}
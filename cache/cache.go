@@ -0,0 +1,46 @@
+// Package cache provides pluggable storage backends for caching FastGPT
+// query/answer pairs.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Entry is a single cached query/answer pair. Format records the
+// --format the answer was rendered with. Backends store Format but do not
+// compare it on Get: entries for different formats are told apart only by
+// the query they are stored under.
+type Entry struct {
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	Format    string    `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Cache stores and retrieves FastGPT responses keyed by query. Get returns
+// ok=false if no entry exists for the query, or if it exists but has expired.
+type Cache interface {
+	Get(query string) (entry Entry, ok bool, err error)
+	Put(query string, entry Entry) error
+	List() ([]Entry, error)
+	Close() error
+}
+
+// Key returns the cache key for a query: the full, un-truncated hex-encoded
+// sha256 of the query text. Using the full hash (rather than a short prefix)
+// avoids collisions between unrelated queries.
+func Key(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// expired reports whether entry is older than ttl. A zero ttl means entries
+// never expire.
+func expired(entry Entry, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+	return time.Since(entry.CreatedAt) > ttl
+}
@@ -0,0 +1,21 @@
+package cache
+
+import "testing"
+
+func TestKeyIsStableAndFull(t *testing.T) {
+	k1 := Key("what is the capital of France")
+	k2 := Key("what is the capital of France")
+	if k1 != k2 {
+		t.Fatalf("Key is not stable: %q != %q", k1, k2)
+	}
+
+	// A full sha256 hex digest is 64 characters; truncating it is exactly
+	// the collision risk this package was introduced to eliminate.
+	if len(k1) != 64 {
+		t.Fatalf("len(Key(...)) = %d, want 64", len(k1))
+	}
+
+	if k1 == Key("what is the capital of Germany") {
+		t.Fatal("Key collided for two different queries")
+	}
+}
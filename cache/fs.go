@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FS is a Cache backed by one JSON file per entry in a directory, the
+// layout the CLI has always used.
+type FS struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewFS returns a Cache that stores entries as JSON files in dir, creating
+// dir if it doesn't already exist. Entries older than ttl are treated as
+// cache misses; a zero ttl disables expiration.
+func NewFS(dir string, ttl time.Duration) (*FS, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &FS{dir: dir, ttl: ttl}, nil
+}
+
+func (f *FS) path(query string) string {
+	return filepath.Join(f.dir, Key(query)+".json")
+}
+
+func (f *FS) Get(query string) (Entry, bool, error) {
+	data, err := os.ReadFile(f.path(query))
+	if os.IsNotExist(err) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, false, fmt.Errorf("failed to unmarshal cache entry: %w", err)
+	}
+	if expired(entry, f.ttl) {
+		return Entry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+func (f *FS) Put(query string, entry Entry) error {
+	jsonEntry, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	if err := os.WriteFile(f.path(query), jsonEntry, 0644); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return nil
+}
+
+func (f *FS) List() ([]Entry, error) {
+	files, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(f.dir, file.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read cache file %q", file.Name())
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal cache file %q", file.Name())
+		}
+		if expired(entry, f.ttl) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (f *FS) Close() error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFSGetPut(t *testing.T) {
+	f, err := NewFS(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewFS: %v", err)
+	}
+
+	if _, ok, err := f.Get("q"); err != nil {
+		t.Fatalf("Get: %v", err)
+	} else if ok {
+		t.Fatal("Get returned ok for an empty cache")
+	}
+
+	if err := f.Put("q", Entry{Question: "q", Answer: "a", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	entry, ok, err := f.Get("q")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !ok {
+		t.Fatal("Get returned !ok after Put")
+	}
+	if entry.Answer != "a" {
+		t.Fatalf("Answer = %q, want %q", entry.Answer, "a")
+	}
+}
+
+func TestFSExpiresEntriesPastTTL(t *testing.T) {
+	f, err := NewFS(t.TempDir(), time.Minute)
+	if err != nil {
+		t.Fatalf("NewFS: %v", err)
+	}
+
+	if err := f.Put("q", Entry{Answer: "a", CreatedAt: time.Now().Add(-2 * time.Minute)}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if _, ok, err := f.Get("q"); err != nil {
+		t.Fatalf("Get: %v", err)
+	} else if ok {
+		t.Fatal("expected expired entry to be a cache miss")
+	}
+
+	entries, err := f.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("List returned %d entries, want 0 expired entries excluded", len(entries))
+	}
+}
+
+func TestFSListReturnsUnexpiredEntries(t *testing.T) {
+	f, err := NewFS(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewFS: %v", err)
+	}
+
+	if err := f.Put("a", Entry{Question: "a", Answer: "1", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := f.Put("b", Entry{Question: "b", Answer: "2", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	entries, err := f.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("List returned %d entries, want 2", len(entries))
+	}
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultMemoryCapacity bounds how many entries an in-memory cache holds
+// before evicting the least recently used one.
+const defaultMemoryCapacity = 256
+
+// Memory is an in-memory, LRU-evicting Cache. It does not persist across
+// process restarts, which makes it a good fit for tests.
+type Memory struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	ll       *list.List // front = most recently used
+	items    map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry Entry
+}
+
+// NewMemory returns an in-memory Cache holding at most capacity entries.
+// A capacity of 0 uses a reasonable default. A zero ttl disables expiration.
+func NewMemory(capacity int, ttl time.Duration) *Memory {
+	if capacity <= 0 {
+		capacity = defaultMemoryCapacity
+	}
+	return &Memory{
+		ttl:      ttl,
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (m *Memory) Get(query string) (Entry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := Key(query)
+	elem, ok := m.items[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+
+	entry := elem.Value.(*memoryItem).entry
+	if expired(entry, m.ttl) {
+		m.ll.Remove(elem)
+		delete(m.items, key)
+		return Entry{}, false, nil
+	}
+
+	m.ll.MoveToFront(elem)
+	return entry, true, nil
+}
+
+func (m *Memory) Put(query string, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := Key(query)
+	if elem, ok := m.items[key]; ok {
+		elem.Value.(*memoryItem).entry = entry
+		m.ll.MoveToFront(elem)
+		return nil
+	}
+
+	elem := m.ll.PushFront(&memoryItem{key: key, entry: entry})
+	m.items[key] = elem
+
+	if m.ll.Len() > m.capacity {
+		oldest := m.ll.Back()
+		if oldest != nil {
+			m.ll.Remove(oldest)
+			delete(m.items, oldest.Value.(*memoryItem).key)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) List() ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var entries []Entry
+	for elem := m.ll.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*memoryItem).entry
+		if expired(entry, m.ttl) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
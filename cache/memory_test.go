@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryGetPut(t *testing.T) {
+	m := NewMemory(0, 0)
+
+	if _, ok, err := m.Get("q"); err != nil {
+		t.Fatalf("Get: %v", err)
+	} else if ok {
+		t.Fatal("Get returned ok for an empty cache")
+	}
+
+	if err := m.Put("q", Entry{Question: "q", Answer: "a"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	entry, ok, err := m.Get("q")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !ok {
+		t.Fatal("Get returned !ok after Put")
+	}
+	if entry.Answer != "a" {
+		t.Fatalf("Answer = %q, want %q", entry.Answer, "a")
+	}
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	m := NewMemory(2, 0)
+
+	m.Put("a", Entry{Answer: "a"})
+	m.Put("b", Entry{Answer: "b"})
+
+	// Touch "a" so "b" becomes the least recently used entry.
+	if _, ok, _ := m.Get("a"); !ok {
+		t.Fatal("expected \"a\" to still be cached")
+	}
+
+	m.Put("c", Entry{Answer: "c"})
+
+	if _, ok, _ := m.Get("b"); ok {
+		t.Fatal("expected \"b\" to have been evicted")
+	}
+	if _, ok, _ := m.Get("a"); !ok {
+		t.Fatal("expected \"a\" to survive eviction")
+	}
+	if _, ok, _ := m.Get("c"); !ok {
+		t.Fatal("expected \"c\" to be cached")
+	}
+}
+
+func TestMemoryExpiresEntriesPastTTL(t *testing.T) {
+	m := NewMemory(0, time.Minute)
+
+	m.Put("q", Entry{Answer: "a", CreatedAt: time.Now().Add(-2 * time.Minute)})
+
+	if _, ok, err := m.Get("q"); err != nil {
+		t.Fatalf("Get: %v", err)
+	} else if ok {
+		t.Fatal("expected expired entry to be a cache miss")
+	}
+}
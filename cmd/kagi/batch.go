@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bcspragu/kagi/api"
+	"github.com/bcspragu/kagi/cache"
+)
+
+// defaultBatchConcurrency is used when --concurrency isn't set or is < 1.
+const defaultBatchConcurrency = 4
+
+// runBatch dispatches every query in the file at command.batchFile
+// concurrently (bounded by command.concurrency) against client, which must
+// be safe for concurrent use. Each response is rendered with renderer and
+// written to <cache_dir>/<hash><ext> if a cache dir is set, where <ext> is
+// formatExt(command.format) (.md for markdown), or to stdout delimited by
+// its query otherwise. A progress summary is printed to
+// stderr as queries complete.
+func runBatch(command Command, client *api.Client, renderer Renderer) error {
+	queries, err := readBatchFile(command.batchFile)
+	if err != nil {
+		return err
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+
+	concurrency := command.concurrency
+	if concurrency < 1 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	var (
+		sem      = make(chan struct{}, concurrency)
+		wg       sync.WaitGroup
+		done     int64
+		failed   int64
+		stdoutMu sync.Mutex
+	)
+
+	for _, query := range queries {
+		query := query
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := runBatchQuery(command, client, renderer, query, &stdoutMu)
+
+			n := atomic.AddInt64(&done, 1)
+			if err != nil {
+				atomic.AddInt64(&failed, 1)
+				fmt.Fprintf(os.Stderr, "[%d/%d] error: %q: %v\n", n, len(queries), query, err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "[%d/%d] done: %q\n", n, len(queries), query)
+		}()
+	}
+	wg.Wait()
+
+	fmt.Fprintf(os.Stderr, "batch complete: %d/%d succeeded\n", int64(len(queries))-failed, len(queries))
+	return nil
+}
+
+func runBatchQuery(command Command, client *api.Client, renderer Renderer, query string, stdoutMu *sync.Mutex) error {
+	resp, err := client.FastGPTRequest(api.FastGPTRequest{
+		Query:     query,
+		WebSearch: true,
+		Cache:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("error performing query: %w", err)
+	}
+
+	if command.cacheDir == "" {
+		stdoutMu.Lock()
+		defer stdoutMu.Unlock()
+
+		fmt.Printf("----- %s -----\n", query)
+		return renderer.Render(os.Stdout, query, resp)
+	}
+
+	if err := os.MkdirAll(command.cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(command.cacheDir, cache.Key(query)+formatExt(command.format)))
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer f.Close()
+
+	return renderer.Render(f, query, resp)
+}
+
+// readBatchFile returns the non-empty, trimmed lines of the file at path,
+// one per query.
+func readBatchFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open batch file: %w", err)
+	}
+	defer f.Close()
+
+	var queries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	return queries, nil
+}
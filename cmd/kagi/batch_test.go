@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadBatchFileSkipsBlankLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.txt")
+	content := "who is the president of France\n\n  what is the capital of Japan  \n\nhow tall is Everest\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	queries, err := readBatchFile(path)
+	if err != nil {
+		t.Fatalf("readBatchFile: %v", err)
+	}
+
+	want := []string{
+		"who is the president of France",
+		"what is the capital of Japan",
+		"how tall is Everest",
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Fatalf("readBatchFile = %#v, want %#v", queries, want)
+	}
+}
+
+func TestReadBatchFileMissing(t *testing.T) {
+	if _, err := readBatchFile(filepath.Join(t.TempDir(), "nope.txt")); err == nil {
+		t.Fatal("expected an error reading a missing batch file")
+	}
+}
@@ -2,23 +2,28 @@ package main
 
 import (
 	"bufio"
-	"crypto/sha256"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/bcspragu/kagi/api"
+	"github.com/bcspragu/kagi/cache"
 
 	"github.com/pkg/errors"
 )
 
 var (
-	errUsage         = errors.New("usage: kagi [flags] query")
-	errMissingAPIKey = errors.New("missing Kagi API key")
+	errUsage                   = errors.New("usage: kagi [flags] query")
+	errMissingAPIKey           = errors.New("missing Kagi API key")
+	errUnknownCacheBackend     = errors.New("unknown cache backend")
+	errReplRequiresCacheDir    = errors.New("--repl requires --cache_dir, so the session transcript can be persisted")
+	errReplRequiresInlineQuery = errors.New("--repl requires the initial query as a command-line argument, since reading it from stdin would consume the input the session needs for its later turns")
+	errUnknownFormat           = errors.New("unknown output format")
 )
 
 func main() {
@@ -36,11 +41,19 @@ func main() {
 }
 
 type Command struct {
-	query      string
-	KagiAPIKey string
-	cacheDir   string
-	verbose    bool
-	flags      flag.FlagSet
+	query        string
+	KagiAPIKey   string
+	cacheDir     string
+	cacheBackend string
+	cacheTTL     time.Duration
+	verbose      bool
+	stream       bool
+	repl         bool
+	session      string
+	format       string
+	batchFile    string
+	concurrency  int
+	flags        flag.FlagSet
 }
 
 func newCommand(args []string) (command Command, err error) {
@@ -48,9 +61,17 @@ func newCommand(args []string) (command Command, err error) {
 	command.flags = *flags
 
 	var (
-		kagiAPIKey = flags.String("kagi_api_key", os.Getenv("KAGI_API_KEY"), "API key to use with the Kagi FastGPT API")
-		cacheDir   = flags.String("cache_dir", "", "Directory to cache API responses in.  If not set, responses will not be cached.")
-		verbose    = flags.Bool("verbose", false, "Enable verbose logging")
+		kagiAPIKey   = flags.String("kagi_api_key", os.Getenv("KAGI_API_KEY"), "API key to use with the Kagi FastGPT API")
+		cacheDir     = flags.String("cache_dir", "", "Directory to cache API responses in.  If not set, responses will not be cached.")
+		cacheBackend = flags.String("cache_backend", "fs", "Cache backend to use: fs, bolt, or memory")
+		cacheTTL     = flags.Duration("cache_ttl", 0, "Expire cache entries older than this duration. 0 disables expiration.")
+		verbose      = flags.Bool("verbose", false, "Enable verbose logging")
+		stream       = flags.Bool("stream", false, "Stream the answer to stdout as it's generated instead of waiting for the full response")
+		repl         = flags.Bool("repl", false, "After the initial query, keep reading prompts from stdin and send them with the prior turns as context")
+		session      = flags.String("session", "", "Resume a previous --repl session by ID instead of starting a new one")
+		format       = flags.String("format", "markdown", "Output format: markdown, plain, json, or html")
+		batchFile    = flags.String("batch", "", "File with one query per line to dispatch concurrently instead of a single query")
+		concurrency  = flags.Int("concurrency", defaultBatchConcurrency, "Max number of --batch queries to run concurrently")
 	)
 
 	if len(os.Args) == 0 {
@@ -67,7 +88,55 @@ func newCommand(args []string) (command Command, err error) {
 
 	command.KagiAPIKey = *kagiAPIKey
 	command.cacheDir = *cacheDir
+	command.cacheBackend = *cacheBackend
+	command.cacheTTL = *cacheTTL
 	command.verbose = *verbose
+	command.stream = *stream
+	command.repl = *repl
+	command.session = *session
+	command.format = *format
+	command.batchFile = *batchFile
+	command.concurrency = *concurrency
+
+	if command.repl && command.batchFile != "" {
+		return command, errors.New("--repl and --batch are mutually exclusive")
+	}
+
+	if command.repl && command.stream {
+		return command, errors.New("--repl and --stream are mutually exclusive: --repl doesn't support streaming output")
+	}
+
+	if command.batchFile != "" && command.stream {
+		return command, errors.New("--batch and --stream are mutually exclusive: --batch doesn't support streaming output")
+	}
+
+	if command.repl && command.cacheDir == "" {
+		return command, errReplRequiresCacheDir
+	}
+
+	if command.stream && command.format != "markdown" {
+		return command, fmt.Errorf("--stream only supports --format markdown, got %q", command.format)
+	}
+
+	if _, err := newRenderer(command.format); err != nil {
+		return command, err
+	}
+
+	// --batch dispatches its own queries and never reads a single query
+	// from args or stdin.
+	if command.batchFile != "" {
+		return command, nil
+	}
+
+	// --repl needs stdin free for its own turn-by-turn reads, so the
+	// initial query must come from args, not the stdin fallback below.
+	if command.repl {
+		if flags.NArg() == 0 {
+			return command, errReplRequiresInlineQuery
+		}
+		command.query = strings.Join(flags.Args(), " ")
+		return command, nil
+	}
 
 	// If no arguments provided, read from stdin
 	if flags.NArg() == 0 {
@@ -97,6 +166,37 @@ func newCommand(args []string) (command Command, err error) {
 func invoke(command Command) error {
 	client := api.NewClient(command.KagiAPIKey)
 
+	if command.repl {
+		return runREPL(command, client)
+	}
+
+	if command.batchFile != "" {
+		renderer, err := newRenderer(command.format)
+		if err != nil {
+			return err
+		}
+		return runBatch(command, client, renderer)
+	}
+
+	var (
+		c   cache.Cache
+		err error
+	)
+	if command.cacheDir != "" {
+		c, err = newCache(command.cacheBackend, command.cacheDir, command.cacheTTL)
+		if err != nil {
+			return fmt.Errorf("error opening cache: %w", err)
+		}
+		defer c.Close()
+
+		if entry, ok, err := c.Get(cacheKey(command.query, command.format)); err != nil {
+			return fmt.Errorf("error reading cache: %w", err)
+		} else if ok {
+			fmt.Print(entry.Answer)
+			return nil
+		}
+	}
+
 	req := api.FastGPTRequest{
 		Query:     command.query,
 		WebSearch: true,
@@ -107,72 +207,174 @@ func invoke(command Command) error {
 		log.Printf("Request: %+v\n", req)
 	}
 
-	resp, err := client.FastGPTRequest(req)
-	if err != nil {
-		return fmt.Errorf("error performing query: %w", err)
-	}
+	var buf strings.Builder
+	out := io.MultiWriter(os.Stdout, &buf)
 
-	response := respond(resp, command.query)
+	if command.stream {
+		events, err := client.FastGPTStream(req)
+		if err != nil {
+			return fmt.Errorf("error starting streaming query: %w", err)
+		}
+		if err := respondStream(out, events, command.query); err != nil {
+			return fmt.Errorf("error streaming response: %w", err)
+		}
+	} else {
+		resp, err := client.FastGPTRequest(req)
+		if err != nil {
+			return fmt.Errorf("error performing query: %w", err)
+		}
 
-	// Send response to stdout
-	fmt.Print(response)
+		renderer, err := newRenderer(command.format)
+		if err != nil {
+			return err
+		}
+		if err := renderer.Render(out, command.query, resp); err != nil {
+			return fmt.Errorf("error rendering response: %w", err)
+		}
+	}
 
-	if command.cacheDir != "" {
-		cache(command.cacheDir, command.query, response)
+	if c != nil {
+		entry := cache.Entry{
+			Question:  command.query,
+			Answer:    buf.String(),
+			Format:    command.format,
+			CreatedAt: time.Now(),
+		}
+		if err := c.Put(cacheKey(command.query, command.format), entry); err != nil {
+			return fmt.Errorf("error writing cache: %w", err)
+		}
 	}
 	return nil
 }
 
-func respond(resp *api.FastGPTResponse, query string) (response string) {
-	// remove all repeated newlines or empty lines from the output
-	answer := strings.ReplaceAll(resp.Data.Output, "\n\n", "\n")
+// cacheKey returns the lookup key used to store/retrieve a query's cached
+// response, folding in the output format so that caching the same query
+// under different --format values doesn't overwrite a different format's
+// entry.
+func cacheKey(query, format string) string {
+	return query + "\x00" + format
+}
 
-	response = "# " + query + "\n" + answer + "\n"
+// newCache builds the Cache implementation named by backend, rooted at dir.
+func newCache(backend, dir string, ttl time.Duration) (cache.Cache, error) {
+	switch backend {
+	case "fs", "":
+		return cache.NewFS(dir, ttl)
+	case "bolt":
+		return cache.NewBolt(filepath.Join(dir, "kagi-cache.db"), ttl)
+	case "memory":
+		return cache.NewMemory(0, ttl), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownCacheBackend, backend)
+	}
+}
 
-	// If there are no references, return early
-	if len(resp.Data.References) == 0 {
-		return
+// runREPL services an interactive, multi-turn conversation: it answers the
+// initial query, then keeps reading prompts from stdin, sending each with
+// the session's prior turns as context, until stdin is closed. The
+// transcript is persisted after every turn so it can be resumed with
+// --session.
+func runREPL(command Command, client *api.Client) error {
+	renderer, err := newRenderer(command.format)
+	if err != nil {
+		return err
 	}
 
-	response += "\n# References\n"
+	sessionID := command.session
+	if sessionID == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		sessionID = id
+	}
 
-	for i, ref := range resp.Data.References {
-		response += fmt.Sprintf("%d. %s - %s  - %s\n", i+1, ref.Title, ref.Link, ref.Snippet)
+	session, err := loadSession(command.cacheDir, sessionID)
+	if err != nil {
+		return fmt.Errorf("error loading session: %w", err)
 	}
+	session.ID = sessionID
+
+	fmt.Fprintf(os.Stderr, "Session %s. Type your query and press Enter (Ctrl+D to exit).\n", session.ID)
+
+	query := command.query
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		if query == "" {
+			fmt.Fprint(os.Stderr, "> ")
+			if !scanner.Scan() {
+				return scanner.Err()
+			}
+			query = strings.TrimSpace(scanner.Text())
+			if query == "" {
+				continue
+			}
+		}
+
+		resp, err := client.FastGPTRequest(api.FastGPTRequest{
+			Query:     query,
+			WebSearch: true,
+			Cache:     true,
+			History:   session.Turns,
+		})
+		if err != nil {
+			return fmt.Errorf("error performing query: %w", err)
+		}
+
+		if err := renderer.Render(os.Stdout, query, resp); err != nil {
+			return fmt.Errorf("error rendering response: %w", err)
+		}
+
+		session.append(api.Turn{Query: query, Answer: resp.Data.Output})
+		if err := session.save(command.cacheDir); err != nil {
+			return fmt.Errorf("error saving session: %w", err)
+		}
 
-	return
+		query = ""
+	}
 }
 
-type CacheEntry struct {
-	Question string `json:"question"`
-	Answer   string `json:"answer"`
+// respond writes the markdown-formatted answer and its references to w.
+func respond(w io.Writer, resp *api.FastGPTResponse, query string) {
+	// remove all repeated newlines or empty lines from the output
+	answer := strings.ReplaceAll(resp.Data.Output, "\n\n", "\n")
+
+	fmt.Fprintf(w, "# %s\n%s\n", query, answer)
+
+	writeReferences(w, resp.Data.References)
 }
 
-func cache(cacheDir string, question string, answer string) error {
-	// create cache directory if it doesn't exist
-	if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(cacheDir, 0755); err != nil {
-			return fmt.Errorf("failed to create cache directory: %w", err)
+// respondStream writes the markdown-formatted answer to w as tokens arrive
+// on events, printing references only once the stream completes
+// successfully. It returns the first error reported by the stream, if any.
+func respondStream(w io.Writer, events <-chan api.Event, query string) error {
+	fmt.Fprintf(w, "# %s\n", query)
+
+	var references []api.Reference
+	for event := range events {
+		if event.Err != nil {
+			return event.Err
+		}
+		if event.Token != "" {
+			fmt.Fprint(w, event.Token)
+		}
+		if event.Done {
+			references = event.References
 		}
 	}
+	fmt.Fprint(w, "\n")
 
-	// write response to cache file
-	// filename is a sha256 hash of the query with a json extension
-	// the filecontent is the json response from the API and the query
-	entry := CacheEntry{
-		Question: question,
-		Answer:   answer,
-	}
+	writeReferences(w, references)
+	return nil
+}
 
-	jsonEntry, err := json.Marshal(entry)
-	if err != nil {
-		return fmt.Errorf("failed to marshal cache entry: %w", err)
+func writeReferences(w io.Writer, references []api.Reference) {
+	if len(references) == 0 {
+		return
 	}
 
-	cacheFile := fmt.Sprintf("%s/%s.json", cacheDir, fmt.Sprintf("%x", sha256.Sum256([]byte(question)))[0:8])
-	if err := os.WriteFile(cacheFile, jsonEntry, 0644); err != nil {
-		return fmt.Errorf("failed to write cache file: %w", err)
+	fmt.Fprint(w, "\n# References\n")
+	for i, ref := range references {
+		fmt.Fprintf(w, "%d. %s - %s  - %s\n", i+1, ref.Title, ref.Link, ref.Snippet)
 	}
-
-	return nil
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/bcspragu/kagi/api"
+)
+
+// Renderer formats a FastGPT response for a particular output medium,
+// selected via --format.
+type Renderer interface {
+	Render(w io.Writer, query string, resp *api.FastGPTResponse) error
+}
+
+// newRenderer returns the Renderer named by format.
+func newRenderer(format string) (Renderer, error) {
+	switch format {
+	case "markdown", "":
+		return markdownRenderer{}, nil
+	case "plain":
+		return plainRenderer{}, nil
+	case "json":
+		return jsonRenderer{}, nil
+	case "html":
+		return htmlRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownFormat, format)
+	}
+}
+
+// formatExt returns the file extension conventionally used for format's
+// output, for callers (like --batch) that write rendered responses to
+// files named after the query.
+func formatExt(format string) string {
+	switch format {
+	case "plain":
+		return ".txt"
+	case "json":
+		return ".json"
+	case "html":
+		return ".html"
+	default:
+		return ".md"
+	}
+}
+
+// markdownRenderer is the tool's original output: the query as a heading,
+// the answer, and a numbered references list.
+type markdownRenderer struct{}
+
+func (markdownRenderer) Render(w io.Writer, query string, resp *api.FastGPTResponse) error {
+	respond(w, resp, query)
+	return nil
+}
+
+// plainRenderer emits the answer only, with no query heading or references,
+// for piping into other tools.
+type plainRenderer struct{}
+
+func (plainRenderer) Render(w io.Writer, _ string, resp *api.FastGPTResponse) error {
+	answer := strings.ReplaceAll(resp.Data.Output, "\n\n", "\n")
+	_, err := fmt.Fprintln(w, answer)
+	return err
+}
+
+// jsonRenderer emits {query, answer, references[]}, for scripting and jq
+// pipelines.
+type jsonRenderer struct{}
+
+type jsonDoc struct {
+	Query      string          `json:"query"`
+	Answer     string          `json:"answer"`
+	References []api.Reference `json:"references"`
+}
+
+func (jsonRenderer) Render(w io.Writer, query string, resp *api.FastGPTResponse) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonDoc{
+		Query:      query,
+		Answer:     resp.Data.Output,
+		References: resp.Data.References,
+	})
+}
+
+// htmlRenderer emits the answer and references as a minimal HTML fragment.
+type htmlRenderer struct{}
+
+func (htmlRenderer) Render(w io.Writer, query string, resp *api.FastGPTResponse) error {
+	fmt.Fprintf(w, "<h1>%s</h1>\n<p>%s</p>\n", html.EscapeString(query), html.EscapeString(resp.Data.Output))
+
+	if len(resp.Data.References) == 0 {
+		return nil
+	}
+
+	fmt.Fprint(w, "<h2>References</h2>\n<ol>\n")
+	for _, ref := range resp.Data.References {
+		fmt.Fprintf(w, "<li><a href=\"%s\">%s</a> - %s</li>\n", html.EscapeString(ref.Link), html.EscapeString(ref.Title), html.EscapeString(ref.Snippet))
+	}
+	fmt.Fprint(w, "</ol>\n")
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bcspragu/kagi/api"
+)
+
+func testResponse() *api.FastGPTResponse {
+	resp := &api.FastGPTResponse{}
+	resp.Data.Output = "Paris\n\nis the capital"
+	resp.Data.References = []api.Reference{
+		{Title: "Capital of France", Link: "https://example.com/paris", Snippet: "Paris is the capital of France"},
+	}
+	return resp
+}
+
+func TestNewRendererUnknownFormat(t *testing.T) {
+	if _, err := newRenderer("yaml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestMarkdownRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := newRenderer("markdown")
+	if err != nil {
+		t.Fatalf("newRenderer: %v", err)
+	}
+	if err := r.Render(&buf, "capital of France", testResponse()); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "# capital of France\n") {
+		t.Fatalf("markdown output missing query heading: %q", out)
+	}
+	if !strings.Contains(out, "# References\n") {
+		t.Fatalf("markdown output missing references section: %q", out)
+	}
+}
+
+func TestPlainRendererOmitsReferences(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := newRenderer("plain")
+	if err != nil {
+		t.Fatalf("newRenderer: %v", err)
+	}
+	if err := r.Render(&buf, "capital of France", testResponse()); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "capital of France") && strings.HasPrefix(out, "#") {
+		t.Fatalf("plain output should not include a query heading: %q", out)
+	}
+	if strings.Contains(out, "References") {
+		t.Fatalf("plain output should not include references: %q", out)
+	}
+}
+
+func TestJSONRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := newRenderer("json")
+	if err != nil {
+		t.Fatalf("newRenderer: %v", err)
+	}
+	if err := r.Render(&buf, "capital of France", testResponse()); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	var doc jsonDoc
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("json output didn't unmarshal: %v\n%s", err, buf.String())
+	}
+	if doc.Query != "capital of France" {
+		t.Fatalf("Query = %q, want %q", doc.Query, "capital of France")
+	}
+	if len(doc.References) != 1 {
+		t.Fatalf("len(References) = %d, want 1", len(doc.References))
+	}
+}
+
+func TestHTMLRendererEscapesContent(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := newRenderer("html")
+	if err != nil {
+		t.Fatalf("newRenderer: %v", err)
+	}
+
+	resp := &api.FastGPTResponse{}
+	resp.Data.Output = "<script>alert(1)</script>"
+
+	if err := r.Render(&buf, "<b>query</b>", resp); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "<script>") {
+		t.Fatalf("html output did not escape answer: %q", out)
+	}
+	if strings.Contains(out, "<b>query</b>") {
+		t.Fatalf("html output did not escape query: %q", out)
+	}
+}
+
+func TestFormatExt(t *testing.T) {
+	cases := map[string]string{
+		"markdown": ".md",
+		"":         ".md",
+		"plain":    ".txt",
+		"json":     ".json",
+		"html":     ".html",
+	}
+	for format, want := range cases {
+		if got := formatExt(format); got != want {
+			t.Errorf("formatExt(%q) = %q, want %q", format, got, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bcspragu/kagi/api"
+)
+
+// maxSessionTurns bounds how many prior turns are kept and sent back to
+// Kagi as context, so a long-running REPL session stays within the API's
+// allowed request length.
+const maxSessionTurns = 20
+
+// Session is a persisted multi-turn REPL conversation. It's stored as a
+// single JSON file per session ID in the cache dir so a REPL invocation
+// can be resumed later with --session.
+type Session struct {
+	ID    string     `json:"id"`
+	Turns []api.Turn `json:"turns"`
+}
+
+// newSessionID returns a short random identifier for a new session.
+func newSessionID() (string, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func sessionPath(cacheDir, id string) string {
+	return filepath.Join(cacheDir, "session-"+id+".json")
+}
+
+// loadSession reads the session with the given ID from cacheDir, returning
+// a fresh, empty Session if no transcript exists yet.
+func loadSession(cacheDir, id string) (Session, error) {
+	data, err := os.ReadFile(sessionPath(cacheDir, id))
+	if os.IsNotExist(err) {
+		return Session{ID: id}, nil
+	}
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return Session{}, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	return session, nil
+}
+
+// save writes the session transcript to cacheDir.
+func (s *Session) save(cacheDir string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := os.WriteFile(sessionPath(cacheDir, s.ID), data, 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// append records turn in the transcript, truncating the oldest turns once
+// maxSessionTurns is exceeded.
+func (s *Session) append(turn api.Turn) {
+	s.Turns = append(s.Turns, turn)
+	if len(s.Turns) > maxSessionTurns {
+		s.Turns = s.Turns[len(s.Turns)-maxSessionTurns:]
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/bcspragu/kagi/api"
+)
+
+func TestSessionAppendTruncatesOldestTurns(t *testing.T) {
+	var s Session
+
+	for i := 0; i < maxSessionTurns+5; i++ {
+		s.append(api.Turn{Query: strconv.Itoa(i), Answer: "a"})
+	}
+
+	if len(s.Turns) != maxSessionTurns {
+		t.Fatalf("len(Turns) = %d, want %d", len(s.Turns), maxSessionTurns)
+	}
+	// The 5 oldest turns (0-4) are dropped, so the first kept turn is 5.
+	if s.Turns[0].Query != "5" {
+		t.Fatalf("Turns[0].Query = %q, want %q", s.Turns[0].Query, "5")
+	}
+}
+
+func TestLoadSessionMissingReturnsEmpty(t *testing.T) {
+	session, err := loadSession(t.TempDir(), "does-not-exist")
+	if err != nil {
+		t.Fatalf("loadSession: %v", err)
+	}
+	if session.ID != "does-not-exist" {
+		t.Fatalf("ID = %q, want %q", session.ID, "does-not-exist")
+	}
+	if len(session.Turns) != 0 {
+		t.Fatalf("expected no turns for a new session, got %d", len(session.Turns))
+	}
+}
+
+func TestSessionSaveAndLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	session := Session{ID: "abc123"}
+	session.append(api.Turn{Query: "what is Go", Answer: "a programming language"})
+
+	if err := session.save(dir); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded, err := loadSession(dir, "abc123")
+	if err != nil {
+		t.Fatalf("loadSession: %v", err)
+	}
+	if len(loaded.Turns) != 1 {
+		t.Fatalf("len(Turns) = %d, want 1", len(loaded.Turns))
+	}
+	if loaded.Turns[0].Query != "what is Go" {
+		t.Fatalf("Turns[0].Query = %q, want %q", loaded.Turns[0].Query, "what is Go")
+	}
+}
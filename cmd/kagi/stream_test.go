@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bcspragu/kagi/api"
+)
+
+func TestRespondStreamWritesTokensInOrderThenReferences(t *testing.T) {
+	events := make(chan api.Event, 3)
+	events <- api.Event{Token: "Par"}
+	events <- api.Event{Token: "is"}
+	events <- api.Event{Done: true, References: []api.Reference{
+		{Title: "Capital of France", Link: "https://example.com", Snippet: "Paris is the capital"},
+	}}
+	close(events)
+
+	var buf bytes.Buffer
+	if err := respondStream(&buf, events, "capital of France"); err != nil {
+		t.Fatalf("respondStream: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "# capital of France\n") {
+		t.Fatalf("missing query heading: %q", out)
+	}
+	if !strings.Contains(out, "Paris") {
+		t.Fatalf("tokens not concatenated in order: %q", out)
+	}
+	if !strings.Contains(out, "# References\n") {
+		t.Fatalf("missing references section after a Done event: %q", out)
+	}
+	if strings.Index(out, "# References\n") < strings.Index(out, "Paris") {
+		t.Fatalf("references written before the answer tokens: %q", out)
+	}
+}
+
+func TestRespondStreamOmitsReferencesWithoutDone(t *testing.T) {
+	events := make(chan api.Event, 1)
+	events <- api.Event{Token: "partial answer"}
+	close(events)
+
+	var buf bytes.Buffer
+	if err := respondStream(&buf, events, "q"); err != nil {
+		t.Fatalf("respondStream: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "References") {
+		t.Fatalf("expected no references section without a Done event: %q", buf.String())
+	}
+}
+
+func TestRespondStreamReturnsEventErrorWithPartialOutputWritten(t *testing.T) {
+	wantErr := errors.New("upstream closed the connection")
+
+	events := make(chan api.Event, 2)
+	events <- api.Event{Token: "partial"}
+	events <- api.Event{Err: wantErr}
+	close(events)
+
+	var buf bytes.Buffer
+	err := respondStream(&buf, events, "q")
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("respondStream error = %v, want %v", err, wantErr)
+	}
+
+	// The tokens received before the error are still written to w; it's
+	// the caller's responsibility (invoke) to skip caching when an error
+	// is returned, which this case exercises by returning non-nil.
+	if !strings.Contains(buf.String(), "partial") {
+		t.Fatalf("expected partial output to have been written before the error: %q", buf.String())
+	}
+}
+
+func TestNewCommandRejectsStreamWithNonMarkdownFormat(t *testing.T) {
+	_, err := newCommand([]string{"kagi", "--kagi_api_key=x", "--stream", "--format=json", "hello"})
+	if err == nil {
+		t.Fatal("expected an error combining --stream with a non-markdown --format")
+	}
+}
+
+func TestNewCommandAllowsStreamWithMarkdownFormat(t *testing.T) {
+	command, err := newCommand([]string{"kagi", "--kagi_api_key=x", "--stream", "hello"})
+	if err != nil {
+		t.Fatalf("newCommand: %v", err)
+	}
+	if !command.stream {
+		t.Fatal("expected command.stream to be true")
+	}
+}